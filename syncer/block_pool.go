@@ -0,0 +1,348 @@
+package syncer
+
+import (
+	"container/heap"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	// maxDiffBetweenCurrentAndReceivedBlockHeight bounds how far ahead of the
+	// local chain the pool will request blocks, so a handful of fast peers
+	// can't buffer an unbounded number of future blocks in memory.
+	maxDiffBetweenCurrentAndReceivedBlockHeight = 1000
+
+	// blockPoolWorkers is the number of goroutines draining requestsCh.
+	blockPoolWorkers = 8
+
+	// noPeerBackoff is how long a worker waits before re-queueing a height
+	// that no currently-known peer covers, so workers don't spin in a tight
+	// dequeue/re-reject loop while waiting for peers to catch up.
+	noPeerBackoff = 50 * time.Millisecond
+)
+
+// BlockRequest is a single outstanding "fetch this height" unit of work.
+type BlockRequest struct {
+	Height uint64
+}
+
+// peerError reports that a request to a given peer failed. reportError
+// sends it on errorsCh for logging; the re-queue and skip-list update happen
+// in reportError itself.
+type peerError struct {
+	peerID peer.ID
+	height uint64
+	err    error
+}
+
+// blockHeap is a min-heap of blocks ordered by number, so blocks received out
+// of order from different peers can be popped by the consumer in order.
+type blockHeap []*types.Block
+
+func (h blockHeap) Len() int            { return len(h) }
+func (h blockHeap) Less(i, j int) bool  { return h[i].Number() < h[j].Number() }
+func (h blockHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *blockHeap) Push(x interface{}) { *h = append(*h, x.(*types.Block)) }
+
+func (h *blockHeap) Pop() interface{} {
+	old := *h
+	n := len(old)
+	item := old[n-1]
+	*h = old[:n-1]
+
+	return item
+}
+
+// BlockPool dispatches outstanding block heights across every connected peer
+// in parallel and reassembles the results in order, similar to Tendermint's
+// blockchain/v0 pool. A window of pending heights
+// [localLatest+1, localLatest+1+window) is kept in flight at all times.
+type BlockPool struct {
+	logger hclog.Logger
+
+	peerMap        *PeerMap
+	syncPeerClient SyncPeerClient
+	blacklist      *peerBlacklist
+
+	window uint64
+
+	requestsCh chan BlockRequest
+	errorsCh   chan peerError
+
+	mux         sync.Mutex
+	received    blockHeap
+	pending     map[uint64]bool
+	skipList    map[peer.ID]bool
+	nextPeerIdx int
+}
+
+// NewBlockPool creates a BlockPool that fetches blocks in parallel over a
+// sliding window of at most maxDiffBetweenCurrentAndReceivedBlockHeight
+// outstanding heights. blacklist is consulted alongside the pool's own,
+// attempt-local skip list so permanently blacklisted peers are never
+// assigned work.
+func NewBlockPool(
+	logger hclog.Logger,
+	peerMap *PeerMap,
+	syncPeerClient SyncPeerClient,
+	window uint64,
+	blacklist *peerBlacklist,
+) *BlockPool {
+	if window == 0 || window > maxDiffBetweenCurrentAndReceivedBlockHeight {
+		window = maxDiffBetweenCurrentAndReceivedBlockHeight
+	}
+
+	return &BlockPool{
+		logger:         logger.Named("block_pool"),
+		peerMap:        peerMap,
+		syncPeerClient: syncPeerClient,
+		blacklist:      blacklist,
+		window:         window,
+		requestsCh:     make(chan BlockRequest, window),
+		errorsCh:       make(chan peerError, window),
+		received:       make(blockHeap, 0),
+		pending:        make(map[uint64]bool),
+		skipList:       make(map[peer.ID]bool),
+	}
+}
+
+// FetchRange downloads [from, to] (inclusive when to > 0, otherwise open
+// ended) across all connected peers and streams the blocks back, in strict
+// ascending order, on the returned channel. The channel is closed once every
+// height through to has been delivered (when to > 0) or ctx is done; an
+// open-ended fetch is only closed by ctx.
+func (p *BlockPool) FetchRange(ctx ctxDoneAwaiter, from, to uint64, timeout time.Duration) <-chan *types.Block {
+	outCh := make(chan *types.Block)
+
+	for i := 0; i < blockPoolWorkers; i++ {
+		go p.runWorker(ctx, timeout)
+	}
+
+	go p.assignHeights(ctx, from, to)
+	go p.reorderAndEmit(ctx, from, to, outCh)
+	go p.logErrors(ctx)
+
+	return outCh
+}
+
+// logErrors drains errorsCh for the life of the fetch, logging each
+// peer/height failure reportError already re-queued. errorsCh exists
+// purely for observability here - re-queueing itself happens synchronously
+// inside reportError - so a full channel just means a burst of failures
+// went unlogged rather than anything being dropped from retry.
+func (p *BlockPool) logErrors(ctx ctxDoneAwaiter) {
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case e := <-p.errorsCh:
+			p.logger.Warn("block request failed, re-queueing", "peer", e.peerID, "height", e.height, "err", e.err)
+		}
+	}
+}
+
+// ctxDoneAwaiter is the minimal subset of context.Context the pool needs;
+// kept as an interface so callers can pass context.Context directly.
+type ctxDoneAwaiter interface {
+	Done() <-chan struct{}
+}
+
+// assignHeights keeps the sliding window full by pushing the next height onto
+// requestsCh whenever fewer than window heights are pending or received but
+// not yet emitted, so the window advances as blocks are emitted in order.
+func (p *BlockPool) assignHeights(ctx ctxDoneAwaiter, from, to uint64) {
+	next := from
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		p.mux.Lock()
+		windowFull := uint64(len(p.pending)+len(p.received)) >= p.window
+		p.mux.Unlock()
+
+		if windowFull {
+			time.Sleep(10 * time.Millisecond)
+
+			continue
+		}
+
+		if to > 0 && next > to {
+			return
+		}
+
+		p.mux.Lock()
+		p.pending[next] = true
+		p.mux.Unlock()
+
+		select {
+		case p.requestsCh <- BlockRequest{Height: next}:
+			next++
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+// runWorker pulls requests off requestsCh, finds a peer whose announced tip
+// covers the requested height, and fetches the block from it. Failures go
+// through reportError, which re-queues the height against another peer.
+func (p *BlockPool) runWorker(ctx ctxDoneAwaiter, timeout time.Duration) {
+	for {
+		select {
+		case req := <-p.requestsCh:
+			p.serveRequest(req, timeout)
+		case <-ctx.Done():
+			return
+		}
+	}
+}
+
+func (p *BlockPool) serveRequest(req BlockRequest, timeout time.Duration) {
+	peerID, ok := p.assignPeer(req.Height)
+	if !ok {
+		// no peer currently covers this height; back off before re-queueing
+		// so this worker doesn't spin re-rejecting the same height
+		time.Sleep(noPeerBackoff)
+		p.requestsCh <- req
+
+		return
+	}
+
+	blockCh, err := p.syncPeerClient.GetBlocks(peerID, req.Height, timeout)
+	if err != nil {
+		p.reportError(req.Height, peerID, err)
+
+		return
+	}
+
+	block, ok := <-blockCh
+
+	if closeErr := p.syncPeerClient.CloseStream(peerID); closeErr != nil {
+		p.logger.Warn("failed to close stream", "peer", peerID, "err", closeErr)
+	}
+
+	if !ok || block == nil {
+		p.reportError(req.Height, peerID, fmt.Errorf("peer closed stream before sending height %d", req.Height))
+
+		return
+	}
+
+	p.mux.Lock()
+	heap.Push(&p.received, block)
+	delete(p.pending, req.Height)
+	p.mux.Unlock()
+}
+
+// assignPeer picks a peer whose reported height covers h, round-robining
+// across every eligible peer rather than always handing work to the single
+// globally-best one - otherwise every worker would pile onto the same peer
+// and the "parallel" pool would only ever use one connection.
+func (p *BlockPool) assignPeer(h uint64) (peer.ID, bool) {
+	eligible := p.eligiblePeers(h)
+	if len(eligible) == 0 {
+		return "", false
+	}
+
+	p.mux.Lock()
+	idx := p.nextPeerIdx % len(eligible)
+	p.nextPeerIdx++
+	p.mux.Unlock()
+
+	return eligible[idx].ID, true
+}
+
+// eligiblePeers returns every peer tracked by the pool's PeerMap whose
+// announced height is at least h and that isn't in the attempt-local skip
+// list or the permanent blacklist. PeerMap only exposes a "best single
+// peer" query, so this walks it repeatedly, excluding each peer it
+// returns, to enumerate every candidate.
+func (p *BlockPool) eligiblePeers(h uint64) []*NoForkPeer {
+	p.mux.Lock()
+	excluded := make(map[peer.ID]bool, len(p.skipList))
+	for k, v := range p.skipList {
+		excluded[k] = v
+	}
+	p.mux.Unlock()
+
+	excluded = p.blacklist.withSkip(excluded)
+
+	var eligible []*NoForkPeer
+
+	for {
+		candidate := p.peerMap.BestPeer(excluded)
+		if candidate == nil || candidate.Number < h {
+			break
+		}
+
+		eligible = append(eligible, candidate)
+		excluded[candidate.ID] = true
+	}
+
+	return eligible
+}
+
+func (p *BlockPool) reportError(height uint64, peerID peer.ID, err error) {
+	// height stays in p.pending across the retry - it's still in flight,
+	// just against a different peer next time - so assignHeights' window
+	// check keeps counting it as outstanding work instead of admitting a
+	// new height and letting real concurrent fetches exceed the window.
+	p.mux.Lock()
+	p.skipList[peerID] = true
+	p.mux.Unlock()
+
+	select {
+	case p.errorsCh <- peerError{peerID: peerID, height: height, err: err}:
+	default:
+	}
+
+	// re-queue the height against the remaining peers
+	p.requestsCh <- BlockRequest{Height: height}
+}
+
+// reorderAndEmit pops blocks off the min-heap in strict ascending order and
+// writes them to outCh, closing it once every height through to has been
+// delivered (when to > 0) or once ctx is done.
+func (p *BlockPool) reorderAndEmit(ctx ctxDoneAwaiter, from, to uint64, outCh chan<- *types.Block) {
+	defer close(outCh)
+
+	next := from
+
+	for {
+		if to > 0 && next > to {
+			return
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		default:
+		}
+
+		p.mux.Lock()
+		if len(p.received) == 0 || p.received[0].Number() != next {
+			p.mux.Unlock()
+			time.Sleep(10 * time.Millisecond)
+
+			continue
+		}
+
+		block := heap.Pop(&p.received).(*types.Block)
+		p.mux.Unlock()
+
+		select {
+		case outCh <- block:
+			next++
+		case <-ctx.Done():
+			return
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package syncer
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+// fakeBlockClient serves each requested height from an in-memory map of
+// blocks and records which peer ID served each height, so tests can assert
+// on load distribution.
+type fakeBlockClient struct {
+	SyncPeerClient
+
+	blocks map[uint64]*types.Block
+
+	mux      sync.Mutex
+	servedBy map[uint64]peer.ID
+}
+
+func (f *fakeBlockClient) GetBlocks(peerID peer.ID, from uint64, _ time.Duration) (chan *types.Block, error) {
+	f.mux.Lock()
+	f.servedBy[from] = peerID
+	f.mux.Unlock()
+
+	ch := make(chan *types.Block, 1)
+
+	if block, ok := f.blocks[from]; ok {
+		ch <- block
+	}
+
+	close(ch)
+
+	return ch, nil
+}
+
+func (f *fakeBlockClient) CloseStream(peer.ID) error {
+	return nil
+}
+
+func testBlock(number uint64) *types.Block {
+	return &types.Block{Header: &types.Header{Number: number}}
+}
+
+func TestBlockPool_FetchRange_ClosesOutputOnceRangeIsComplete(t *testing.T) {
+	peerMap := new(PeerMap)
+	peerMap.Put(&NoForkPeer{ID: "peer-1", Number: 20})
+
+	blocks := make(map[uint64]*types.Block, 10)
+	for i := uint64(1); i <= 10; i++ {
+		blocks[i] = testBlock(i)
+	}
+
+	client := &fakeBlockClient{blocks: blocks, servedBy: make(map[uint64]peer.ID)}
+	pool := NewBlockPool(hclog.NewNullLogger(), peerMap, client, 4, newPeerBlacklist())
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	blockCh := pool.FetchRange(ctx, 1, 10, time.Second)
+
+	var received []uint64
+	for block := range blockCh {
+		received = append(received, block.Number())
+	}
+
+	expected := []uint64{1, 2, 3, 4, 5, 6, 7, 8, 9, 10}
+	assert.Equal(t, expected, received, "FetchRange must close its output channel once every height through `to` is delivered")
+}
+
+func TestBlockPool_AssignPeer_DistributesAcrossEligiblePeers(t *testing.T) {
+	peerMap := new(PeerMap)
+	peerMap.Put(
+		&NoForkPeer{ID: "peer-1", Number: 20},
+		&NoForkPeer{ID: "peer-2", Number: 20},
+		&NoForkPeer{ID: "peer-3", Number: 20},
+	)
+
+	pool := NewBlockPool(hclog.NewNullLogger(), peerMap, &fakeBlockClient{}, 4, newPeerBlacklist())
+
+	seen := make(map[peer.ID]bool)
+	for i := 0; i < 6; i++ {
+		id, ok := pool.assignPeer(1)
+		assert.True(t, ok)
+		seen[id] = true
+	}
+
+	assert.Len(t, seen, 3, "assignPeer should round-robin across every eligible peer, not always pick the same one")
+}
@@ -0,0 +1,159 @@
+package syncer
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+const (
+	// HeadersProto is the sub-protocol used to request header-only ranges
+	// ahead of full block bodies, enabling FastSync.
+	HeadersProto = "/syncer/headers/0.1"
+
+	// headersBatchSize is how many headers are requested per GetHeaders call.
+	headersBatchSize = 192
+)
+
+// stageHeaders and stageBlocks name the two phases of FastSync so
+// CurrentSyncStage can report which one is active. GetSyncProgression does
+// not carry the stage name.
+const (
+	stageHeaders = "headers"
+	stageBlocks  = "blocks"
+)
+
+// Checkpoint pins a known-good (number, hash) pair that the downloaded
+// header chain must pass through during FastSync. Reaching a checkpoint's
+// height with a mismatching hash means the serving peer is on the wrong
+// chain (or lying), so it is disconnected and blacklisted.
+type Checkpoint struct {
+	Number uint64
+	Hash   types.Hash
+}
+
+// WithCheckpoints configures the checkpoints FastSync asserts against while
+// downloading the header chain.
+func WithCheckpoints(checkpoints []Checkpoint) SyncerOption {
+	return func(s *syncer) {
+		s.checkpoints = checkpoints
+	}
+}
+
+// FastSync performs a headers-first sync: the header chain up to the best
+// peer's announced tip is downloaded and verified first, asserting every
+// configured checkpoint along the way, and only then are block bodies
+// fetched - in parallel, per header range, via a BlockPool - and checked
+// against the already-downloaded headers before being written to the chain.
+func (s *syncer) FastSync(ctx context.Context, newBlockCallback func(*types.Block) bool) error {
+	localLatest := uint64(0)
+	if header := s.blockchain.Header(); header != nil {
+		localLatest = header.Number
+	}
+
+	s.syncProgression.StartProgression(localLatest+1, s.blockchain.SubscribeEvents())
+	defer s.syncProgression.StopProgression()
+
+	defer func() { s.syncStage = stageBlocks }()
+
+	bestPeer := s.peerMap.BestPeer(s.blacklist.withSkip(nil))
+	if bestPeer == nil || bestPeer.Number <= localLatest {
+		return nil
+	}
+
+	if !s.supportsHeadersFirstSync(bestPeer.ID) {
+		return fmt.Errorf("peer %s did not negotiate a protocol version new enough for FastSync", bestPeer.ID)
+	}
+
+	s.syncProgression.UpdateHighestProgression(bestPeer.Number)
+
+	s.syncStage = stageHeaders
+
+	headers, err := s.downloadHeaderChain(bestPeer.ID, localLatest+1, bestPeer.Number)
+	if err != nil {
+		return fmt.Errorf("headers-first sync failed to download header chain: %w", err)
+	}
+
+	s.syncStage = stageBlocks
+
+	pool := NewBlockPool(s.logger, s.peerMap, s.syncPeerClient, s.blockPoolWindow, s.blacklist)
+	blockCh := pool.FetchRange(ctx, localLatest+1, bestPeer.Number, s.blockTimeout)
+
+	for block := range blockCh {
+		header, ok := headers[block.Number()]
+		if !ok || header.Hash != block.Header.Hash {
+			return fmt.Errorf("block %d does not match previously downloaded header", block.Number())
+		}
+
+		if err := s.blockchain.VerifyFinalizedBlock(block); err != nil {
+			return fmt.Errorf("unable to verify block, %w", err)
+		}
+
+		if err := s.blockchain.WriteBlock(block); err != nil {
+			return fmt.Errorf("failed to write block while fast syncing: %w", err)
+		}
+
+		if newBlockCallback(block) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
+// downloadHeaderChain fetches [from, to] headers from peerID in batches,
+// asserting any configured checkpoint hash along the way. A checkpoint
+// mismatch disconnects and blacklists the peer.
+func (s *syncer) downloadHeaderChain(peerID peer.ID, from, to uint64) (map[uint64]*types.Header, error) {
+	headers := make(map[uint64]*types.Header, to-from+1)
+
+	for start := from; start <= to; start += headersBatchSize {
+		count := headersBatchSize
+		if remaining := to - start + 1; remaining < uint64(count) {
+			count = int(remaining)
+		}
+
+		batch, err := s.syncPeerClient.GetHeaders(peerID, start, count)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get headers [%d, %d) from peer: %w", start, start+uint64(count), err)
+		}
+
+		for _, header := range batch {
+			if checkpoint, ok := s.checkpointFor(header.Number); ok && checkpoint.Hash != header.Hash {
+				s.blacklistPeer(peerID)
+
+				return nil, fmt.Errorf("checkpoint mismatch at height %d for peer %s", header.Number, peerID)
+			}
+
+			headers[header.Number] = header
+		}
+	}
+
+	return headers, nil
+}
+
+// checkpointFor returns the checkpoint pinned at number, if any.
+func (s *syncer) checkpointFor(number uint64) (Checkpoint, bool) {
+	for _, c := range s.checkpoints {
+		if c.Number == number {
+			return c, true
+		}
+	}
+
+	return Checkpoint{}, false
+}
+
+// blacklistPeer disconnects a peer that served an invalid checkpoint hash,
+// removes it from the peer map, and permanently blacklists it so it can
+// never be selected or re-added to the peer map again, even across future
+// status updates or reconnects.
+func (s *syncer) blacklistPeer(peerID peer.ID) {
+	s.blacklist.add(peerID)
+	s.peerMap.Remove(peerID)
+
+	if err := s.syncPeerClient.DisconnectFromPeer(peerID, "invalid checkpoint hash"); err != nil {
+		s.logger.Warn("failed to disconnect blacklisted peer", "peer", peerID, "err", err)
+	}
+}
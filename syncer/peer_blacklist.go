@@ -0,0 +1,58 @@
+package syncer
+
+import (
+	"sync"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// peerBlacklist is a persistent (for the life of the syncer) set of peers
+// that have proven dishonest - e.g. served a block or header hash that
+// doesn't match a configured checkpoint. Unlike the per-attempt skipList
+// used locally by BulkSync/WatchSync, a blacklisted peer is never retried:
+// it is excluded from every future BestPeer lookup and from peer-map
+// insertion, so a status update or reconnect can't bring it back.
+type peerBlacklist struct {
+	mux sync.Mutex
+	ids map[peer.ID]bool
+}
+
+func newPeerBlacklist() *peerBlacklist {
+	return &peerBlacklist{ids: make(map[peer.ID]bool)}
+}
+
+// add permanently blacklists id.
+func (b *peerBlacklist) add(id peer.ID) {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	b.ids[id] = true
+}
+
+// contains reports whether id has been blacklisted.
+func (b *peerBlacklist) contains(id peer.ID) bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	return b.ids[id]
+}
+
+// withSkip returns skip merged with every blacklisted peer, suitable for
+// passing straight to PeerMap.BestPeer so blacklisted peers are never
+// selected again.
+func (b *peerBlacklist) withSkip(skip map[peer.ID]bool) map[peer.ID]bool {
+	b.mux.Lock()
+	defer b.mux.Unlock()
+
+	merged := make(map[peer.ID]bool, len(skip)+len(b.ids))
+
+	for id := range skip {
+		merged[id] = true
+	}
+
+	for id := range b.ids {
+		merged[id] = true
+	}
+
+	return merged
+}
@@ -0,0 +1,72 @@
+package syncer
+
+import (
+	"github.com/libp2p/go-libp2p-core/peer"
+)
+
+// supportedProtocolVersions lists every SyncerProto version this node's
+// SyncPeerService registers a libp2p stream handler for, newest first.
+// SyncPeerClient negotiates down to the highest version a given peer also
+// advertises via libp2p's multistream-select, so a future wire change can be
+// rolled out by adding a new entry here without breaking peers still on an
+// older version.
+var supportedProtocolVersions = []string{
+	"/syncer/0.3",
+	SyncerProto, // "/syncer/0.2", kept for peers that haven't upgraded yet
+}
+
+// protocolVersionHeadersFirstSync is the minimum negotiated version a peer
+// must support to be asked for the /syncer/headers/0.1 RPCs FastSync needs.
+const protocolVersionHeadersFirstSync = "/syncer/0.3"
+
+// SupportedVersions returns every SyncerProto version this node can speak,
+// newest first.
+func SupportedVersions() []string {
+	versions := make([]string, len(supportedProtocolVersions))
+	copy(versions, supportedProtocolVersions)
+
+	return versions
+}
+
+// WithMinProtocolVersion configures the lowest negotiated SyncerProto
+// version meetsMinVersion accepts. Peers are not checked against it when
+// they are added to the peer map, so none is refused on this floor.
+func WithMinProtocolVersion(version string) SyncerOption {
+	return func(s *syncer) {
+		s.minProtocolVersion = version
+	}
+}
+
+// meetsMinVersion reports whether negotiated is at least as new as the
+// configured MinProtocolVersion.
+func (s *syncer) meetsMinVersion(negotiated string) bool {
+	if s.minProtocolVersion == "" {
+		return true
+	}
+
+	return protocolVersionRank(negotiated) <= protocolVersionRank(s.minProtocolVersion)
+}
+
+// supportsHeadersFirstSync reports whether peerID negotiated a protocol
+// version new enough to serve the headers-first RPCs FastSync needs.
+func (s *syncer) supportsHeadersFirstSync(peerID peer.ID) bool {
+	negotiated, ok := s.syncPeerClient.NegotiatedVersion(peerID)
+	if !ok {
+		return false
+	}
+
+	return protocolVersionRank(negotiated) <= protocolVersionRank(protocolVersionHeadersFirstSync)
+}
+
+// protocolVersionRank ranks a version by its position in
+// supportedProtocolVersions (lower is newer); an unrecognized version ranks
+// last, i.e. is treated as older than everything this node knows about.
+func protocolVersionRank(version string) int {
+	for i, v := range supportedProtocolVersions {
+		if v == version {
+			return i
+		}
+	}
+
+	return len(supportedProtocolVersions)
+}
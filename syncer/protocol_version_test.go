@@ -0,0 +1,56 @@
+package syncer
+
+import (
+	"testing"
+
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProtocolVersionRank_UnknownVersionRanksLast(t *testing.T) {
+	assert.Equal(t, len(supportedProtocolVersions), protocolVersionRank("/syncer/0.1"))
+}
+
+func TestProtocolVersionRank_OrdersNewestFirst(t *testing.T) {
+	assert.Less(t, protocolVersionRank("/syncer/0.3"), protocolVersionRank(SyncerProto))
+}
+
+func TestSyncer_MeetsMinVersion_GatesBothDirections(t *testing.T) {
+	s := &syncer{minProtocolVersion: "/syncer/0.3"}
+
+	assert.True(t, s.meetsMinVersion("/syncer/0.3"), "negotiating exactly the floor must be accepted")
+	assert.False(t, s.meetsMinVersion(SyncerProto), "negotiating below the floor must be rejected")
+}
+
+func TestSyncer_MeetsMinVersion_NoFloorConfigured(t *testing.T) {
+	s := &syncer{}
+
+	assert.True(t, s.meetsMinVersion(SyncerProto), "an empty MinProtocolVersion must accept any recognized version")
+}
+
+// fakeVersionClient is a minimal SyncPeerClient that only implements
+// NegotiatedVersion, for testing version-gated behavior in isolation.
+type fakeVersionClient struct {
+	SyncPeerClient
+
+	versions map[peer.ID]string
+}
+
+func (f *fakeVersionClient) NegotiatedVersion(id peer.ID) (string, bool) {
+	v, ok := f.versions[id]
+
+	return v, ok
+}
+
+func TestSyncer_SupportsHeadersFirstSync(t *testing.T) {
+	client := &fakeVersionClient{versions: map[peer.ID]string{
+		"new-peer": "/syncer/0.3",
+		"old-peer": SyncerProto,
+	}}
+
+	s := &syncer{syncPeerClient: client}
+
+	assert.True(t, s.supportsHeadersFirstSync("new-peer"), "a peer on the newest version must support headers-first sync")
+	assert.False(t, s.supportsHeadersFirstSync("old-peer"), "a peer on an older version must not support headers-first sync")
+	assert.False(t, s.supportsHeadersFirstSync("unknown-peer"), "a peer with no negotiated version must not support headers-first sync")
+}
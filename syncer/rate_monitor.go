@@ -0,0 +1,93 @@
+package syncer
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// defaultMinRecvRate is the minimum sustained bytes/sec a peer must
+	// maintain during bulk sync before it's considered stalled.
+	defaultMinRecvRate = 1024 // 1 KB/s
+
+	// defaultMaxStallDuration is how long a peer may make zero progress
+	// before it's disconnected, even if no single block timeout has fired.
+	defaultMaxStallDuration = 3 * time.Minute
+
+	// stallSampleInterval is how often bulkSyncWithPeer samples the rate
+	// monitor to check whether the current peer has stalled.
+	stallSampleInterval = 30 * time.Second
+)
+
+// rateMonitor tracks how fast a peer is delivering blocks during a single
+// bulkSyncWithPeer stream, so a peer that is technically still sending data
+// - just too slowly, or not at all - can be detected and dropped even
+// though no individual block timeout has fired. Borrowed from the pool
+// design in Tendermint and the stall detection in lbcd's netsync manager.
+//
+// The receive rate is windowed, not a lifetime average: each call to
+// isStalled that gets past the no-progress check measures bytes received
+// since the window last reset and resets the counters, so a peer that bursts blocks early and then trickles is judged
+// on its current behavior rather than being propped up by history.
+type rateMonitor struct {
+	mux sync.Mutex
+
+	windowStart    time.Time
+	windowBytes    uint64
+	windowBlocks   uint64
+	lastProgressAt time.Time
+}
+
+func newRateMonitor() *rateMonitor {
+	now := time.Now()
+
+	return &rateMonitor{
+		windowStart:    now,
+		lastProgressAt: now,
+	}
+}
+
+// recordBlock registers a newly received block of the given wire size and
+// resets the stall clock.
+func (r *rateMonitor) recordBlock(size uint64) {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	r.windowBytes += size
+	r.windowBlocks++
+	r.lastProgressAt = time.Now()
+}
+
+// stalledFor returns how long it's been since the last block was received.
+func (r *rateMonitor) stalledFor() time.Duration {
+	r.mux.Lock()
+	defer r.mux.Unlock()
+
+	return time.Since(r.lastProgressAt)
+}
+
+// isStalled reports whether the monitored peer should be considered
+// stalled: either it has made no progress at all for maxStallDuration, or
+// its receive rate over the window since the previous call has dropped
+// below minRecvRate. Intended to be called once per stallSampleInterval
+// tick, which it uses as the window boundary.
+func (r *rateMonitor) isStalled(minRecvRate float64, maxStallDuration time.Duration) bool {
+	if r.stalledFor() >= maxStallDuration {
+		return true
+	}
+
+	r.mux.Lock()
+	elapsed := time.Since(r.windowStart).Seconds()
+	bytes := r.windowBytes
+
+	r.windowStart = time.Now()
+	r.windowBytes = 0
+	r.windowBlocks = 0
+	r.mux.Unlock()
+
+	if elapsed <= 0 {
+		return false
+	}
+
+	return float64(bytes)/elapsed < minRecvRate
+}
@@ -0,0 +1,57 @@
+package syncer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRateMonitor_IsStalled_NoProgressExceedsMaxStall(t *testing.T) {
+	r := newRateMonitor()
+	r.lastProgressAt = time.Now().Add(-2 * time.Minute)
+
+	assert.True(t, r.isStalled(1, time.Minute), "no progress for longer than maxStallDuration must be reported as stalled")
+}
+
+func TestRateMonitor_IsStalled_RateAboveThreshold(t *testing.T) {
+	r := newRateMonitor()
+	r.recordBlock(10_000)
+	r.windowStart = time.Now().Add(-time.Second)
+
+	assert.False(t, r.isStalled(1_000, time.Hour), "10KB/s over the window must not be considered stalled against a 1KB/s floor")
+}
+
+func TestRateMonitor_IsStalled_RateBelowThreshold(t *testing.T) {
+	r := newRateMonitor()
+	r.recordBlock(10)
+	r.windowStart = time.Now().Add(-time.Second)
+
+	assert.True(t, r.isStalled(1_000, time.Hour), "10B/s over the window must be considered stalled against a 1KB/s floor")
+}
+
+func TestRateMonitor_IsStalled_ElapsedZeroIsNotYetJudged(t *testing.T) {
+	r := newRateMonitor()
+	// time.Since a window opened by newRateMonitor is already a few ns, so
+	// start the window ahead of now to leave no elapsed time at all.
+	r.windowStart = time.Now().Add(time.Hour)
+
+	assert.False(t, r.isStalled(1_000_000, time.Hour), "a window with no elapsed time must not be judged on rate yet")
+}
+
+func TestRateMonitor_IsStalled_ResetsWindowEachCall(t *testing.T) {
+	r := newRateMonitor()
+	r.recordBlock(10)
+	r.windowStart = time.Now().Add(-time.Second)
+
+	// First call samples the 10-byte, one-second window and must reset it.
+	r.isStalled(1, time.Hour)
+
+	r.mux.Lock()
+	windowBytes := r.windowBytes
+	windowBlocks := r.windowBlocks
+	r.mux.Unlock()
+
+	assert.Zero(t, windowBytes, "isStalled must reset windowBytes after sampling it")
+	assert.Zero(t, windowBlocks, "isStalled must reset windowBlocks after sampling it")
+}
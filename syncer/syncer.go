@@ -1,9 +1,19 @@
+// Package syncer syncs the local chain against the network's best peer.
+//
+// Shutdown order matters: Close cancels the syncer's internal context
+// first, so initializePeerMap, startPeerConnectionEventProcess and the
+// peer-status worker pool all stop selecting on their input channels. Only
+// then are syncPeerService and syncPeerClient stopped, which closes the
+// channels those goroutines read from. Close then waits on the syncer's
+// WaitGroup before closing newStatusCh, so nothing can still be sending on
+// it by the time it closes.
 package syncer
 
 import (
 	"context"
 	"errors"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/0xPolygon/polygon-edge/helper/progress"
@@ -16,10 +26,38 @@ import (
 const (
 	syncerLoggerName = "syncer"
 	SyncerProto      = "/syncer/0.2"
+
+	// defaultBlockPoolWindow is how many heights SyncModeParallel keeps in
+	// flight at once when no window size is otherwise configured.
+	defaultBlockPoolWindow = 100
+
+	// peerStatusWorkers bounds how many goroutines concurrently fetch a
+	// newly connected peer's status, replacing the previous unbounded
+	// spawn-a-goroutine-per-PeerConnected-event pattern.
+	peerStatusWorkers = 4
+
+	// peerStatusQueueSize is the buffer on the channel feeding the peer
+	// status worker pool.
+	peerStatusQueueSize = peerStatusWorkers * 4
+)
+
+// SyncMode selects how BulkSync retrieves blocks from the network.
+type SyncMode int
+
+const (
+	// SyncModeSequential pulls blocks from a single best peer, falling back
+	// to the next best peer only when the current one errors out. This is
+	// the syncer's original behavior and remains the default.
+	SyncModeSequential SyncMode = iota
+
+	// SyncModeParallel dispatches outstanding block heights across all
+	// connected peers concurrently via a BlockPool.
+	SyncModeParallel
 )
 
 var (
-	errTimeout = errors.New("timeout awaiting block from peer")
+	errTimeout     = errors.New("timeout awaiting block from peer")
+	errStalledPeer = errors.New("peer stalled: receive rate below minimum or no progress")
 )
 
 // XXX: Don't use this syncer for the consensus that may cause fork.
@@ -39,6 +77,88 @@ type syncer struct {
 
 	// Channel to notify WatchSync that a new status arrived
 	newStatusCh chan struct{}
+
+	// syncMode controls whether BulkSync fetches from a single peer at a
+	// time or fans requests out across all connected peers
+	syncMode SyncMode
+
+	// blockPoolWindow is the number of heights SyncModeParallel keeps in
+	// flight at once
+	blockPoolWindow uint64
+
+	// checkpoints are asserted against the downloaded header chain during
+	// FastSync; a hash mismatch blacklists the serving peer
+	checkpoints []Checkpoint
+
+	// syncStage names the active phase of FastSync ("headers" or "blocks")
+	// so CurrentSyncStage can report which one is in progress
+	syncStage string
+
+	// minRecvRate is the minimum sustained bytes/sec a peer must maintain
+	// during bulk sync before it's considered stalled
+	minRecvRate float64
+
+	// maxStallDuration is how long a peer may make zero progress before
+	// it's disconnected, even if no single block timeout has fired
+	maxStallDuration time.Duration
+
+	// ctx/cancel govern the lifetime of the syncer's long-lived goroutines;
+	// Close cancels ctx before tearing anything else down
+	ctx    context.Context
+	cancel context.CancelFunc
+
+	// wg tracks every long-lived goroutine the syncer owns, so Close can
+	// wait for them to exit before closing newStatusCh
+	wg sync.WaitGroup
+
+	// peerStatusCh feeds the bounded peer-status worker pool; populated by
+	// startPeerConnectionEventProcess on PeerConnected events
+	peerStatusCh chan peer.ID
+
+	// minProtocolVersion is the lowest negotiated SyncerProto version
+	// meetsMinVersion accepts. Empty means no floor. The syncer does not
+	// call meetsMinVersion when adding peers, so no peer is refused on it.
+	minProtocolVersion string
+
+	// blacklist holds peers that proved dishonest (e.g. an invalid
+	// checkpoint hash) and must never be synced with again
+	blacklist *peerBlacklist
+}
+
+// SyncerOption configures optional syncer behavior on top of NewSyncer's
+// required arguments.
+type SyncerOption func(*syncer)
+
+// WithSyncMode overrides the default SyncModeSequential behavior.
+func WithSyncMode(mode SyncMode) SyncerOption {
+	return func(s *syncer) {
+		s.syncMode = mode
+	}
+}
+
+// WithBlockPoolWindow overrides the number of heights kept in flight when
+// running in SyncModeParallel. Defaults to defaultBlockPoolWindow.
+func WithBlockPoolWindow(window uint64) SyncerOption {
+	return func(s *syncer) {
+		s.blockPoolWindow = window
+	}
+}
+
+// WithMinRecvRate overrides the minimum sustained bytes/sec a peer must
+// maintain during bulk sync before it's dropped as stalled. Defaults to
+// defaultMinRecvRate.
+func WithMinRecvRate(bytesPerSec float64) SyncerOption {
+	return func(s *syncer) {
+		s.minRecvRate = bytesPerSec
+	}
+}
+
+// WithMaxStallDuration overrides how long a peer may make zero progress
+// before it's dropped as stalled. Defaults to defaultMaxStallDuration.
+func WithMaxStallDuration(d time.Duration) SyncerOption {
+	return func(s *syncer) {
+		s.maxStallDuration = d
+	}
 }
 
 func NewSyncer(
@@ -46,36 +166,64 @@ func NewSyncer(
 	network Network,
 	blockchain Blockchain,
 	blockTimeout time.Duration,
+	opts ...SyncerOption,
 ) Syncer {
-	return &syncer{
-		logger:          logger.Named(syncerLoggerName),
-		blockchain:      blockchain,
-		syncProgression: progress.NewProgressionWrapper(progress.ChainSyncBulk),
-		syncPeerService: NewSyncPeerService(network, blockchain),
-		syncPeerClient:  NewSyncPeerClient(logger, network, blockchain),
-		blockTimeout:    blockTimeout,
-		newStatusCh:     make(chan struct{}),
-		peerMap:         new(PeerMap),
+	s := &syncer{
+		logger:           logger.Named(syncerLoggerName),
+		blockchain:       blockchain,
+		syncProgression:  progress.NewProgressionWrapper(progress.ChainSyncBulk),
+		syncPeerService:  NewSyncPeerService(network, blockchain),
+		syncPeerClient:   NewSyncPeerClient(logger, network, blockchain),
+		blockTimeout:     blockTimeout,
+		newStatusCh:      make(chan struct{}),
+		peerMap:          new(PeerMap),
+		peerStatusCh:     make(chan peer.ID, peerStatusQueueSize),
+		syncMode:         SyncModeSequential,
+		blockPoolWindow:  defaultBlockPoolWindow,
+		syncStage:        stageBlocks,
+		minRecvRate:      defaultMinRecvRate,
+		maxStallDuration: defaultMaxStallDuration,
+		blacklist:        newPeerBlacklist(),
 	}
+
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
 }
 
 // Start starts goroutine processes
 func (s *syncer) Start() error {
+	s.ctx, s.cancel = context.WithCancel(context.Background())
+
 	if err := s.syncPeerClient.Start(); err != nil {
 		return err
 	}
 
 	s.syncPeerService.Start()
 
+	s.wg.Add(2 + peerStatusWorkers)
+
 	go s.initializePeerMap()
 	go s.startPeerConnectionEventProcess()
 
+	for i := 0; i < peerStatusWorkers; i++ {
+		go s.runPeerStatusWorker()
+	}
+
 	return nil
 }
 
-// Close terminates goroutine processes
+// Close terminates goroutine processes. See the package comment for the
+// shutdown order this follows and why it matters.
 func (s *syncer) Close() error {
-	close(s.newStatusCh)
+	// cancel is only set once Start has run; Close must tolerate being
+	// called on a syncer that never started (e.g. Start returned an error
+	// and the caller still defers Close).
+	if s.cancel != nil {
+		s.cancel()
+	}
 
 	if err := s.syncPeerService.Close(); err != nil {
 		return err
@@ -83,57 +231,137 @@ func (s *syncer) Close() error {
 
 	s.syncPeerClient.Close()
 
+	s.wg.Wait()
+
+	close(s.newStatusCh)
+
 	return nil
 }
 
 // initializePeerMap fetches peer statuses and initializes map
 func (s *syncer) initializePeerMap() {
+	defer s.wg.Done()
+
 	peerStatuses := s.syncPeerClient.GetConnectedPeerStatuses()
-	s.peerMap.Put(peerStatuses...)
+	for _, status := range peerStatuses {
+		if !s.blacklist.contains(status.ID) {
+			s.peerMap.Put(status)
+		}
+	}
 
-	for peerStatus := range s.syncPeerClient.GetPeerStatusUpdateCh() {
-		s.peerMap.Put(peerStatus)
+	statusCh := s.syncPeerClient.GetPeerStatusUpdateCh()
 
+	for {
 		select {
-		case s.newStatusCh <- struct{}{}:
-		default:
+		case <-s.ctx.Done():
+			return
+		case peerStatus, ok := <-statusCh:
+			if !ok {
+				return
+			}
+
+			if s.blacklist.contains(peerStatus.ID) {
+				continue
+			}
+
+			s.peerMap.Put(peerStatus)
+			s.notifyNewStatus()
 		}
 	}
 }
 
-// startPeerConnectionEventProcess processes peer connection change events
+// startPeerConnectionEventProcess processes peer connection change events.
+// PeerConnected events are handed off to a bounded worker pool
+// (runPeerStatusWorker) rather than spawned as an ad-hoc goroutine per
+// event, so the number of in-flight status fetches - and writes to
+// newStatusCh - stays bounded and stoppable.
 func (s *syncer) startPeerConnectionEventProcess() {
-	for e := range s.syncPeerClient.GetPeerConnectionUpdateEventCh() {
-		peerID := e.PeerID
+	defer s.wg.Done()
+	defer close(s.peerStatusCh)
 
-		switch e.Type {
-		case event.PeerConnected:
-			go func() {
-				status, err := s.syncPeerClient.GetPeerStatus(peerID)
-				if err != nil {
-					s.logger.Warn("failed to get peer status, skip", "id", peerID, "err", err)
+	eventCh := s.syncPeerClient.GetPeerConnectionUpdateEventCh()
 
-					return
-				}
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case e, ok := <-eventCh:
+			if !ok {
+				return
+			}
 
-				s.peerMap.Put(status)
+			switch e.Type {
+			case event.PeerConnected:
+				if s.blacklist.contains(e.PeerID) {
+					continue
+				}
 
 				select {
-				case s.newStatusCh <- struct{}{}:
-				default:
+				case s.peerStatusCh <- e.PeerID:
+				case <-s.ctx.Done():
+					return
 				}
-			}()
-		case event.PeerDisconnected:
-			s.peerMap.Remove(peerID)
+			case event.PeerDisconnected:
+				s.peerMap.Remove(e.PeerID)
+			}
 		}
 	}
 }
 
+// runPeerStatusWorker is one of peerStatusWorkers goroutines draining
+// peerStatusCh to fetch a newly connected peer's status.
+func (s *syncer) runPeerStatusWorker() {
+	defer s.wg.Done()
+
+	for {
+		select {
+		case <-s.ctx.Done():
+			return
+		case peerID, ok := <-s.peerStatusCh:
+			if !ok {
+				return
+			}
+
+			if s.blacklist.contains(peerID) {
+				continue
+			}
+
+			status, err := s.syncPeerClient.GetPeerStatus(peerID)
+			if err != nil {
+				s.logger.Warn("failed to get peer status, skip", "id", peerID, "err", err)
+
+				continue
+			}
+
+			s.peerMap.Put(status)
+			s.notifyNewStatus()
+		}
+	}
+}
+
+// notifyNewStatus wakes up WatchSync without blocking, unless the syncer is
+// shutting down.
+func (s *syncer) notifyNewStatus() {
+	select {
+	case s.newStatusCh <- struct{}{}:
+	case <-s.ctx.Done():
+	default:
+	}
+}
+
 // GetSyncProgression returns progression
 func (s *syncer) GetSyncProgression() *progress.Progression {
 	return s.syncProgression.GetProgression()
 }
 
+// CurrentSyncStage reports which phase of FastSync is active ("headers" or
+// "blocks"), so callers can distinguish header-chain download from block
+// body download in progress reporting. Outside of FastSync this is always
+// "blocks".
+func (s *syncer) CurrentSyncStage() string {
+	return s.syncStage
+}
+
 // HasSyncPeer returns whether syncer has the peer to syncs blocks
 // return false if syncer has no peer whose latest block height doesn't exceed local height
 func (s *syncer) HasSyncPeer() bool {
@@ -141,7 +369,7 @@ func (s *syncer) HasSyncPeer() bool {
 		return false
 	}
 
-	bestPeer := s.peerMap.BestPeer(nil)
+	bestPeer := s.peerMap.BestPeer(s.blacklist.withSkip(nil))
 	header := s.blockchain.Header()
 
 	return bestPeer != nil && bestPeer.Number > header.Number
@@ -163,10 +391,14 @@ func (s *syncer) BulkSync(ctx context.Context, newBlockCallback func(*types.Bloc
 	// Stop monitoring the sync progression upon exit
 	defer s.syncProgression.StopProgression()
 
+	if s.syncMode == SyncModeParallel {
+		return s.bulkSyncParallel(ctx, localLatest, newBlockCallback)
+	}
+
 	skipList := make(map[peer.ID]bool)
 
 	for {
-		bestPeer := s.peerMap.BestPeer(skipList)
+		bestPeer := s.peerMap.BestPeer(s.blacklist.withSkip(skipList))
 		if bestPeer == nil || bestPeer.Number <= localLatest {
 			break
 		}
@@ -193,6 +425,41 @@ func (s *syncer) BulkSync(ctx context.Context, newBlockCallback func(*types.Bloc
 	return nil
 }
 
+// bulkSyncParallel fetches [localLatest+1, bestPeer.Number] by fanning
+// requests out across every connected peer via a BlockPool, writing blocks
+// to the local chain strictly in order as they arrive.
+func (s *syncer) bulkSyncParallel(
+	ctx context.Context,
+	localLatest uint64,
+	newBlockCallback func(*types.Block) bool,
+) error {
+	bestPeer := s.peerMap.BestPeer(s.blacklist.withSkip(nil))
+	if bestPeer == nil || bestPeer.Number <= localLatest {
+		return nil
+	}
+
+	s.syncProgression.UpdateHighestProgression(bestPeer.Number)
+
+	pool := NewBlockPool(s.logger, s.peerMap, s.syncPeerClient, s.blockPoolWindow, s.blacklist)
+	blockCh := pool.FetchRange(ctx, localLatest+1, bestPeer.Number, s.blockTimeout)
+
+	for block := range blockCh {
+		if err := s.blockchain.VerifyFinalizedBlock(block); err != nil {
+			return fmt.Errorf("unable to verify block, %w", err)
+		}
+
+		if err := s.blockchain.WriteBlock(block); err != nil {
+			return fmt.Errorf("failed to write block while bulk syncing: %w", err)
+		}
+
+		if newBlockCallback(block) {
+			return nil
+		}
+	}
+
+	return nil
+}
+
 // WatchSync syncs block with the best peer until callback returns true
 func (s *syncer) WatchSync(ctx context.Context, callback func(*types.Block) bool) error {
 	localLatest := s.blockchain.Header().Number
@@ -209,7 +476,7 @@ func (s *syncer) WatchSync(ctx context.Context, callback func(*types.Block) bool
 		}
 
 		// pick one best peer
-		bestPeer := s.peerMap.BestPeer(skipList)
+		bestPeer := s.peerMap.BestPeer(s.blacklist.withSkip(skipList))
 		if bestPeer == nil {
 			// Empty skipList map if there are no best peers
 			skipList = make(map[peer.ID]bool)
@@ -263,6 +530,11 @@ func (s *syncer) bulkSyncWithPeer(peerID peer.ID, newBlockCallback func(*types.B
 
 	var lastReceivedNumber uint64
 
+	monitor := newRateMonitor()
+
+	stallTicker := time.NewTicker(stallSampleInterval)
+	defer stallTicker.Stop()
+
 	for {
 		select {
 		case block, ok := <-blockCh:
@@ -283,9 +555,19 @@ func (s *syncer) bulkSyncWithPeer(peerID peer.ID, newBlockCallback func(*types.B
 				return lastReceivedNumber, false, fmt.Errorf("failed to write block while bulk syncing: %w", err)
 			}
 
+			monitor.recordBlock(block.Size())
+
 			shouldTerminate = newBlockCallback(block)
 
 			lastReceivedNumber = block.Number()
+		case <-stallTicker.C:
+			if monitor.isStalled(s.minRecvRate, s.maxStallDuration) {
+				if err := s.syncPeerClient.CloseStream(peerID); err != nil {
+					s.logger.Error("Failed to close stream for stalled peer: ", err)
+				}
+
+				return lastReceivedNumber, shouldTerminate, errStalledPeer
+			}
 		case <-time.After(s.blockTimeout):
 			return lastReceivedNumber, shouldTerminate, errTimeout
 		}
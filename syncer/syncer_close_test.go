@@ -0,0 +1,90 @@
+package syncer
+
+import (
+	"testing"
+	"time"
+
+	"github.com/0xPolygon/polygon-edge/network/event"
+	"github.com/0xPolygon/polygon-edge/types"
+	"github.com/hashicorp/go-hclog"
+	"github.com/libp2p/go-libp2p-core/peer"
+	"github.com/stretchr/testify/assert"
+)
+
+type fakeSyncPeerService struct{}
+
+func (f *fakeSyncPeerService) Start()       {}
+func (f *fakeSyncPeerService) Close() error { return nil }
+
+// fakeSyncPeerClient is the minimal SyncPeerClient needed to exercise
+// Start/Close without a real libp2p network.
+type fakeSyncPeerClient struct {
+	statusCh    chan *NoForkPeer
+	connEventCh chan event.PeerEvent
+}
+
+func newFakeSyncPeerClient() *fakeSyncPeerClient {
+	return &fakeSyncPeerClient{
+		statusCh:    make(chan *NoForkPeer),
+		connEventCh: make(chan event.PeerEvent),
+	}
+}
+
+func (f *fakeSyncPeerClient) Start() error { return nil }
+
+func (f *fakeSyncPeerClient) Close() {
+	close(f.statusCh)
+	close(f.connEventCh)
+}
+
+func (f *fakeSyncPeerClient) GetConnectedPeerStatuses() []*NoForkPeer { return nil }
+func (f *fakeSyncPeerClient) GetPeerStatusUpdateCh() chan *NoForkPeer { return f.statusCh }
+
+func (f *fakeSyncPeerClient) GetPeerConnectionUpdateEventCh() chan event.PeerEvent {
+	return f.connEventCh
+}
+
+func (f *fakeSyncPeerClient) GetPeerStatus(peer.ID) (*NoForkPeer, error) { return nil, nil }
+
+func (f *fakeSyncPeerClient) GetBlocks(peer.ID, uint64, time.Duration) (chan *types.Block, error) {
+	return nil, nil
+}
+
+func (f *fakeSyncPeerClient) CloseStream(peer.ID) error { return nil }
+
+func (f *fakeSyncPeerClient) GetHeaders(peer.ID, uint64, int) ([]*types.Header, error) {
+	return nil, nil
+}
+
+func (f *fakeSyncPeerClient) DisconnectFromPeer(peer.ID, string) error { return nil }
+func (f *fakeSyncPeerClient) NegotiatedVersion(peer.ID) (string, bool) { return "", false }
+
+func newTestSyncer() *syncer {
+	return &syncer{
+		logger:          hclog.NewNullLogger(),
+		syncPeerService: &fakeSyncPeerService{},
+		syncPeerClient:  newFakeSyncPeerClient(),
+		newStatusCh:     make(chan struct{}),
+		peerMap:         new(PeerMap),
+		peerStatusCh:    make(chan peer.ID, peerStatusQueueSize),
+		blacklist:       newPeerBlacklist(),
+	}
+}
+
+func TestSyncer_Close_WithoutStart_DoesNotPanic(t *testing.T) {
+	s := newTestSyncer()
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, s.Close())
+	})
+}
+
+func TestSyncer_StartThenClose_ShutsDownWithoutPanicking(t *testing.T) {
+	s := newTestSyncer()
+
+	assert.NoError(t, s.Start())
+
+	assert.NotPanics(t, func() {
+		assert.NoError(t, s.Close())
+	})
+}